@@ -1,56 +1,473 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+	core_v1 "k8s.io/api/core/v1"
 	policy "k8s.io/api/policy/v1beta1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	runtimeutil "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/uuid"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typed_core_v1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	core_listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/util/workqueue"
 	_ "k8s.io/klog"
+	metrics_v1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
 	metricsv "k8s.io/metrics/pkg/client/clientset/versioned"
 )
 
 const (
-	EvictionKind                             = "Eviction"
-	PreoomkillerPodLabelSelector             = "preoomkiller-enabled=true"
-	PreoomkillerAnnotationMemoryThresholdKey = "preoomkiller.alpha.k8s.zapier.com/memory-threshold"
+	EvictionKind                              = "Eviction"
+	PreoomkillerPodLabelSelector              = "preoomkiller-enabled=true"
+	PreoomkillerAnnotationMemoryThresholdKey  = "preoomkiller.alpha.k8s.zapier.com/memory-threshold"
+	PreoomkillerAnnotationPreventEvictionKey  = "preoomkiller.alpha.k8s.zapier.com/prevent-eviction"
+	PreoomkillerNamespaceLabelPreventEviction = "preoomkiller.alpha.k8s.zapier.com/prevent-eviction"
+	PreoomkillerAnnotationPreEvictHTTPKey     = "preoomkiller.alpha.k8s.zapier.com/pre-evict-http"
+	PreoomkillerAnnotationPreEvictExecKey     = "preoomkiller.alpha.k8s.zapier.com/pre-evict-exec"
+	PreoomkillerAnnotationPreEvictTimeoutKey  = "preoomkiller.alpha.k8s.zapier.com/pre-evict-timeout"
+	PreoomkillerAnnotationForceOnPDBKey       = "preoomkiller.alpha.k8s.zapier.com/force-on-pdb"
+	PreoomkillerAnnotationGracePeriodKey      = "preoomkiller.alpha.k8s.zapier.com/grace-period-seconds"
+
+	defaultPreEvictTimeout = 30 * time.Second
+
+	// evictionBackoffInitial, evictionBackoffFactor and evictionBackoffMax drive the
+	// exponential jitter backoff evictPodWithRetry uses while an Eviction is blocked by a
+	// PodDisruptionBudget, mirroring the retry strategy used by kubectl's drain helper.
+	evictionBackoffInitial = 1 * time.Second
+	evictionBackoffFactor  = 2.0
+	evictionBackoffMax     = 30 * time.Second
+	evictionBackoffJitter  = 0.3
 )
 
-// Controller is responsible for ensuring that pods matching PreoomkillerPodLabelSelector
-// are evicted.
+// numWorkers is the number of goroutines draining the reconciliation workqueue.
+const numWorkers = 2
+
+// Controller watches pods matching PreoomkillerPodLabelSelector via a shared informer
+// (the source of truth for pod set membership) and evicts any whose container memory
+// usage exceeds its threshold. Pod metrics are polled in a single batched List call
+// every interval, cached, and used to drive reconciliation through a workqueue.
 type Controller struct {
 	clientset        kubernetes.Interface
 	metricsClientset *metricsv.Clientset
+	restConfig       *rest.Config
 	interval         time.Duration
+
+	informerFactory informers.SharedInformerFactory
+	podInformer     cache.SharedIndexInformer
+	podLister       core_listers.PodLister
+	queue           workqueue.RateLimitingInterface
+
+	// nsInformerFactory is a separate, untweaked SharedInformerFactory for Namespaces:
+	// informerFactory applies PreoomkillerPodLabelSelector to every informer it builds,
+	// which would wrongly filter namespaces too.
+	nsInformerFactory informers.SharedInformerFactory
+	namespaceInformer cache.SharedIndexInformer
+	namespaceLister   core_listers.NamespaceLister
+
+	metricsMu    sync.Mutex
+	metricsCache map[string]*metrics_v1beta1.PodMetrics
+
+	// knownContainersMu guards knownContainers, the set of container names most recently
+	// given podMemoryUsageBytes/podMemoryThresholdBytes labels for a pod (keyed by
+	// namespace/name), so their series can be deleted once the pod is gone instead of
+	// accumulating forever.
+	knownContainersMu sync.Mutex
+	knownContainers   map[string][]string
+
+	// defaultMemoryThresholdPercent, when > 0, is used as a container's memory threshold
+	// (as a percentage of its resources.limits.memory) when neither a per-container nor
+	// a pod-level memory-threshold annotation is present.
+	defaultMemoryThresholdPercent float64
+
+	// evictionCooldown is the minimum time to wait before re-evaluating a pod that was
+	// just evicted, keyed by UID in lastEvictionTime. Entries are forgotten via
+	// forgetEviction once the pod is deleted, so this doesn't grow for the controller's
+	// entire lifetime.
+	evictionCooldown time.Duration
+	lastEvictionMu   sync.Mutex
+	lastEvictionTime map[types.UID]time.Time
+
+	// evictionRateLimiter globally throttles evictions to --max-evictions-per-minute
+	// across all pods, to avoid cascading evictions when many pods breach thresholds
+	// at once. Nil means unlimited. Gated with Allow rather than Reserve/Delay so a
+	// skipped eviction doesn't consume a token it never used.
+	evictionRateLimiter *rate.Limiter
+
+	// evictionTimeout bounds how long evictPodWithRetry backs off against a
+	// PodDisruptionBudget-blocked Eviction before giving up on a single pod.
+	evictionTimeout time.Duration
+
+	// pdbRetryState tracks, per pod key, the in-progress backoff state for a
+	// PodDisruptionBudget-blocked eviction that evictPodWithRetry is retrying across
+	// separate syncPod calls via the workqueue, rather than blocking a worker goroutine.
+	pdbRetryMu    sync.Mutex
+	pdbRetryState map[string]*pdbRetry
+
+	eventRecorder record.EventRecorder
+}
+
+func NewController(clientset kubernetes.Interface, metricsClientset *metricsv.Clientset, restConfig *rest.Config, interval time.Duration, defaultMemoryThresholdPercent float64, evictionCooldown time.Duration, maxEvictionsPerMinute int, evictionTimeout time.Duration) *Controller {
+	var rateLimiter *rate.Limiter
+	if maxEvictionsPerMinute > 0 {
+		rateLimiter = rate.NewLimiter(rate.Limit(float64(maxEvictionsPerMinute)/60.0), maxEvictionsPerMinute)
+	}
+
+	informerFactory := informers.NewSharedInformerFactoryWithOptions(clientset, interval,
+		informers.WithTweakListOptions(func(opts *meta_v1.ListOptions) {
+			opts.LabelSelector = PreoomkillerPodLabelSelector
+		}),
+	)
+	podInformer := informerFactory.Core().V1().Pods()
+
+	nsInformerFactory := informers.NewSharedInformerFactory(clientset, interval)
+	namespaceInformer := nsInformerFactory.Core().V1().Namespaces()
+
+	c := &Controller{
+		clientset:                     clientset,
+		metricsClientset:              metricsClientset,
+		restConfig:                    restConfig,
+		interval:                      interval,
+		informerFactory:               informerFactory,
+		podInformer:                   podInformer.Informer(),
+		podLister:                     podInformer.Lister(),
+		queue:                         workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		nsInformerFactory:             nsInformerFactory,
+		namespaceInformer:             namespaceInformer.Informer(),
+		namespaceLister:               namespaceInformer.Lister(),
+		metricsCache:                  make(map[string]*metrics_v1beta1.PodMetrics),
+		knownContainers:               make(map[string][]string),
+		defaultMemoryThresholdPercent: defaultMemoryThresholdPercent,
+		evictionCooldown:              evictionCooldown,
+		lastEvictionTime:              make(map[types.UID]time.Time),
+		evictionRateLimiter:           rateLimiter,
+		evictionTimeout:               evictionTimeout,
+		pdbRetryState:                 make(map[string]*pdbRetry),
+		eventRecorder:                 newEventRecorder(clientset),
+	}
+
+	c.podInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.enqueuePod,
+		UpdateFunc: func(_, newObj interface{}) { c.enqueuePod(newObj) },
+		DeleteFunc: c.onPodDelete,
+	})
+
+	return c
+}
+
+// enqueuePod adds a pod's namespace/name key to the reconciliation workqueue.
+func (c *Controller) enqueuePod(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		log.Errorf("EnqueuePodError: %s", err)
+		return
+	}
+	c.queue.Add(key)
+}
+
+// onPodDelete forgets a deleted pod's eviction cooldown entry, keyed by UID in
+// lastEvictionTime so it can't be cleaned up once the object is gone from the lister,
+// before enqueuing it as usual so syncPod's IsNotFound branch can clear its
+// PodDisruptionBudget retry and metrics state.
+func (c *Controller) onPodDelete(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	if pod, ok := obj.(*core_v1.Pod); ok {
+		c.forgetEviction(pod.ObjectMeta.UID)
+	}
+	c.enqueuePod(obj)
+}
+
+// newEventRecorder builds an EventRecorder that publishes Events as the
+// preoomkiller-controller component, for surfacing eviction decisions on the pods
+// they affect.
+func newEventRecorder(clientset kubernetes.Interface) record.EventRecorder {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartLogging(log.Infof)
+	broadcaster.StartRecordingToSink(&typed_core_v1.EventSinkImpl{Interface: clientset.CoreV1().Events("")})
+	return broadcaster.NewRecorder(scheme.Scheme, core_v1.EventSource{Component: "preoomkiller-controller"})
+}
+
+// preventsEviction reports whether pod carries the prevent-eviction annotation, or lives
+// in a namespace labeled with PreoomkillerNamespaceLabelPreventEviction, in which case
+// syncPod should skip it entirely regardless of its memory usage. Namespace lookups go
+// through c.namespaceLister, which is backed by a watch, so this doesn't cost an API call
+// per reconcile.
+func (c *Controller) preventsEviction(pod *core_v1.Pod) bool {
+	if pod.ObjectMeta.Annotations[PreoomkillerAnnotationPreventEvictionKey] == "true" {
+		return true
+	}
+
+	namespace := pod.ObjectMeta.Namespace
+	ns, err := c.namespaceLister.Get(namespace)
+	if err != nil {
+		log.WithField("namespace", namespace).Warnf("NamespaceFetchError: %s", err)
+		return false
+	}
+	return ns.ObjectMeta.Labels[PreoomkillerNamespaceLabelPreventEviction] == "true"
+}
+
+// inCooldown reports whether uid was evicted within the last c.evictionCooldown.
+func (c *Controller) inCooldown(uid types.UID) bool {
+	c.lastEvictionMu.Lock()
+	defer c.lastEvictionMu.Unlock()
+	last, ok := c.lastEvictionTime[uid]
+	return ok && time.Since(last) < c.evictionCooldown
+}
+
+// recordEviction marks uid as evicted now, starting its cooldown window.
+func (c *Controller) recordEviction(uid types.UID) {
+	c.lastEvictionMu.Lock()
+	defer c.lastEvictionMu.Unlock()
+	c.lastEvictionTime[uid] = time.Now()
+}
+
+// forgetEviction drops uid's cooldown entry, e.g. once its pod has been deleted and can
+// no longer be re-evaluated, so lastEvictionTime doesn't grow for as long as the
+// controller runs.
+func (c *Controller) forgetEviction(uid types.UID) {
+	c.lastEvictionMu.Lock()
+	defer c.lastEvictionMu.Unlock()
+	delete(c.lastEvictionTime, uid)
+}
+
+// preEvictTimeout returns how long to wait for pod's pre-evict hook to complete,
+// from the pre-evict-timeout annotation or defaultPreEvictTimeout if unset/invalid.
+func preEvictTimeout(pod *core_v1.Pod) time.Duration {
+	raw, ok := pod.ObjectMeta.Annotations[PreoomkillerAnnotationPreEvictTimeoutKey]
+	if !ok {
+		return defaultPreEvictTimeout
+	}
+	timeout, err := time.ParseDuration(raw)
+	if err != nil {
+		log.WithFields(log.Fields{"pod": pod.Name, "namespace": pod.Namespace}).Warnf("PreEvictHookError: invalid pre-evict-timeout %q, using default: %s", raw, err)
+		return defaultPreEvictTimeout
+	}
+	return timeout
+}
+
+// runPreEvictHook runs the pod's configured pre-eviction drain hook, if any, and
+// waits for it to finish or time out before evictPod is called. The outcome is
+// recorded as a Kubernetes Event on the pod.
+func (c *Controller) runPreEvictHook(pod *core_v1.Pod) {
+	timeout := preEvictTimeout(pod)
+
+	if rawURL, ok := pod.ObjectMeta.Annotations[PreoomkillerAnnotationPreEvictHTTPKey]; ok {
+		c.runPreEvictHTTPHook(pod, rawURL, timeout)
+		return
+	}
+
+	if cmd, ok := pod.ObjectMeta.Annotations[PreoomkillerAnnotationPreEvictExecKey]; ok {
+		c.runPreEvictExecHook(pod, cmd, timeout)
+		return
+	}
 }
 
-func NewController(clientset kubernetes.Interface, metricsClientset *metricsv.Clientset, interval time.Duration) *Controller {
-	return &Controller{
-		clientset:        clientset,
-		metricsClientset: metricsClientset,
-		interval:         interval,
+// runPreEvictHTTPHook POSTs to the pod IP on the path/port given by rawURL (e.g.
+// "http://:8080/drain") and waits up to timeout for a 2xx response.
+func (c *Controller) runPreEvictHTTPHook(pod *core_v1.Pod, rawURL string, timeout time.Duration) {
+	podLog := log.WithFields(log.Fields{"pod": pod.Name, "namespace": pod.Namespace})
+
+	if pod.Status.PodIP == "" {
+		podLog.Error("PreEvictHookError: pod has no PodIP, skipping pre-evict-http hook")
+		return
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		podLog.Errorf("PreEvictHookError: invalid pre-evict-http annotation %q: %s", rawURL, err)
+		return
+	}
+	u.Host = net.JoinHostPort(pod.Status.PodIP, u.Port())
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), nil)
+	if err != nil {
+		podLog.Errorf("PreEvictHookError: %s", err)
+		c.recordHookEvent(pod, core_v1.EventTypeWarning, "PreEvictHookFailed", err.Error())
+		return
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		podLog.Errorf("PreEvictHookError: pre-evict-http request failed: %s", err)
+		c.recordHookEvent(pod, core_v1.EventTypeWarning, "PreEvictHookFailed", err.Error())
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		podLog.Warnf("PreEvictHookFailed: pre-evict-http returned status %d", resp.StatusCode)
+		c.recordHookEvent(pod, core_v1.EventTypeWarning, "PreEvictHookFailed", fmt.Sprintf("pre-evict-http returned status %d", resp.StatusCode))
+		return
 	}
+
+	podLog.Info("PreEvictHookSucceeded: pre-evict-http completed")
+	c.recordHookEvent(pod, core_v1.EventTypeNormal, "PreEvictHookSucceeded", "pre-evict-http completed")
 }
 
-// evictPod attempts to evict a pod in a given namespace
-func evictPod(client kubernetes.Interface, podName, podNamespace, policyGroupVersion string, dryRun bool) (bool, error) {
+// runPreEvictExecHook runs cmd inside the pod's first container via the pod exec
+// subresource and waits up to timeout for it to finish, mirroring how kubelet probes
+// invoke commands in containers.
+func (c *Controller) runPreEvictExecHook(pod *core_v1.Pod, cmd string, timeout time.Duration) {
+	podLog := log.WithFields(log.Fields{"pod": pod.Name, "namespace": pod.Namespace})
+
+	if len(pod.Spec.Containers) == 0 {
+		podLog.Error("PreEvictHookError: pod has no containers, skipping pre-evict-exec hook")
+		return
+	}
+
+	req := c.clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(pod.Name).
+		Namespace(pod.Namespace).
+		SubResource("exec").
+		VersionedParams(&core_v1.PodExecOptions{
+			Container: pod.Spec.Containers[0].Name,
+			Command:   strings.Fields(cmd),
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+
+	executor, err := remotecommand.NewSPDYExecutor(c.restConfig, "POST", req.URL())
+	if err != nil {
+		podLog.Errorf("PreEvictHookError: unable to create exec executor: %s", err)
+		c.recordHookEvent(pod, core_v1.EventTypeWarning, "PreEvictHookFailed", err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- executor.Stream(remotecommand.StreamOptions{
+			Stdout: os.Stdout,
+			Stderr: os.Stderr,
+		})
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			podLog.Errorf("PreEvictHookError: pre-evict-exec failed: %s", err)
+			c.recordHookEvent(pod, core_v1.EventTypeWarning, "PreEvictHookFailed", err.Error())
+			return
+		}
+		podLog.Info("PreEvictHookSucceeded: pre-evict-exec completed")
+		c.recordHookEvent(pod, core_v1.EventTypeNormal, "PreEvictHookSucceeded", "pre-evict-exec completed")
+	case <-ctx.Done():
+		podLog.Warnf("PreEvictHookFailed: pre-evict-exec timed out after %s", timeout)
+		c.recordHookEvent(pod, core_v1.EventTypeWarning, "PreEvictHookFailed", fmt.Sprintf("pre-evict-exec timed out after %s", timeout))
+	}
+}
+
+// recordHookEvent publishes a Kubernetes Event on pod reporting a pre-evict hook outcome.
+func (c *Controller) recordHookEvent(pod *core_v1.Pod, eventType, reason, message string) {
+	c.eventRecorder.Event(pod, eventType, reason, message)
+}
+
+// containerMemoryThresholdAnnotationKey returns the annotation key used for a
+// per-container memory threshold override, e.g.
+// "preoomkiller.alpha.k8s.zapier.com/memory-threshold.sidecar".
+func containerMemoryThresholdAnnotationKey(containerName string) string {
+	return fmt.Sprintf("%s.%s", PreoomkillerAnnotationMemoryThresholdKey, containerName)
+}
+
+// containerMemoryThreshold resolves the memory threshold to apply to a single container,
+// preferring (in order) a per-container annotation, the pod-level annotation, and finally
+// a derived percentage of the container's resources.limits.memory.
+func containerMemoryThreshold(pod *core_v1.Pod, container core_v1.Container, defaultMemoryThresholdPercent float64) (resource.Quantity, error) {
+	annotations := pod.ObjectMeta.Annotations
+
+	if raw, ok := annotations[containerMemoryThresholdAnnotationKey(container.Name)]; ok {
+		return resource.ParseQuantity(raw)
+	}
+
+	if raw, ok := annotations[PreoomkillerAnnotationMemoryThresholdKey]; ok {
+		return resource.ParseQuantity(raw)
+	}
+
+	if defaultMemoryThresholdPercent > 0 {
+		limit := container.Resources.Limits.Memory()
+		if limit.IsZero() {
+			return resource.Quantity{}, fmt.Errorf("no memory-threshold annotation and container %q has no memory limit to derive a default from", container.Name)
+		}
+		threshold := limit.DeepCopy()
+		threshold.Set(int64(float64(threshold.Value()) * defaultMemoryThresholdPercent / 100))
+		return threshold, nil
+	}
+
+	return resource.Quantity{}, fmt.Errorf("no memory-threshold annotation found for container %q", container.Name)
+}
+
+// forceOnPDB reports whether pod carries the force-on-pdb annotation, requesting a hard
+// delete fallback when its PodDisruptionBudget keeps blocking a normal Eviction.
+func forceOnPDB(pod *core_v1.Pod) bool {
+	return pod.ObjectMeta.Annotations[PreoomkillerAnnotationForceOnPDBKey] == "true"
+}
+
+// gracePeriodSeconds returns the grace period to delete/evict pod with, from the
+// grace-period-seconds annotation, or nil to use the API server's default.
+func gracePeriodSeconds(pod *core_v1.Pod) *int64 {
+	raw, ok := pod.ObjectMeta.Annotations[PreoomkillerAnnotationGracePeriodKey]
+	if !ok {
+		return nil
+	}
+	seconds, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		log.WithFields(log.Fields{"pod": pod.Name, "namespace": pod.Namespace}).Warnf("GracePeriodAnnotationError: invalid grace-period-seconds %q, using default: %s", raw, err)
+		return nil
+	}
+	return &seconds
+}
+
+// evictPod attempts to evict a pod in a given namespace via the policy/v1beta1 Eviction
+// subresource, the version supported by the client-go release this repo targets (a
+// policy/v1 Eviction type, and the context-free client methods the rest of this file
+// uses, are never both available at once).
+func evictPod(client kubernetes.Interface, podName, podNamespace string, gracePeriodSeconds *int64, dryRun bool) (bool, error) {
 	if dryRun {
 		return true, nil
 	}
-	deleteOptions := &meta_v1.DeleteOptions{}
-	// GracePeriodSeconds ?
+	deleteOptions := &meta_v1.DeleteOptions{GracePeriodSeconds: gracePeriodSeconds}
+
 	eviction := &policy.Eviction{
 		TypeMeta: meta_v1.TypeMeta{
-			APIVersion: policyGroupVersion,
+			APIVersion: "policy/v1beta1",
 			Kind:       EvictionKind,
 		},
 		ObjectMeta: meta_v1.ObjectMeta{
@@ -64,82 +481,376 @@ func evictPod(client kubernetes.Interface, podName, podNamespace, policyGroupVer
 	if err == nil {
 		return true, nil
 	} else if apierrors.IsTooManyRequests(err) {
-		return false, fmt.Errorf("error when evicting pod (ignoring) %q: %v", podName, err)
+		return false, err
 	} else if apierrors.IsNotFound(err) {
-		return true, fmt.Errorf("pod not found when evicting %q: %v", podName, err)
+		return true, fmt.Errorf("pod not found when evicting %q: %w", podName, err)
 	} else {
 		return false, err
 	}
 }
 
-// RunOnce runs one sigle iteration of reconciliation loop
-func (c *Controller) RunOnce() error {
-	evictionCount := 0
+// pdbRetry tracks one pod's in-progress PodDisruptionBudget-blocked eviction retry: the
+// deadline after which evictPodWithRetry gives up, and the next backoff to use.
+type pdbRetry struct {
+	deadline time.Time
+	backoff  time.Duration
+}
+
+// errEvictionRetryQueued is returned by evictPodWithRetry to tell syncPod that a
+// PodDisruptionBudget-blocked Eviction has already been requeued with backoff, and no
+// further action (or error logging) is needed on this pass.
+var errEvictionRetryQueued = errors.New("eviction blocked by PodDisruptionBudget, retry requeued")
+
+// evictPodWithRetry attempts a single Eviction of the pod identified by key. If the
+// Eviction is blocked by a PodDisruptionBudget, it requeues key onto c.queue after an
+// exponential jitter backoff (mirroring kubectl drain's retry strategy) and returns
+// errEvictionRetryQueued, rather than blocking the calling worker goroutine with
+// time.Sleep. Once c.evictionTimeout has elapsed since the first attempt for this key, it
+// gives up and returns the final PodDisruptionBudget error instead.
+func (c *Controller) evictPodWithRetry(key, podName, podNamespace string, gracePeriodSeconds *int64, dryRun bool) (bool, error) {
+	ok, err := evictPod(c.clientset, podName, podNamespace, gracePeriodSeconds, dryRun)
+	if err == nil || !apierrors.IsTooManyRequests(err) {
+		return ok, err
+	}
+
+	if sleep, retry := c.nextPDBBackoff(key); retry {
+		c.queue.AddAfter(key, sleep)
+		return false, errEvictionRetryQueued
+	}
+
+	return false, err
+}
+
+// nextPDBBackoff returns the next backoff duration to wait before retrying a
+// PodDisruptionBudget-blocked eviction of key, and false once c.evictionTimeout has
+// elapsed since the first attempt. Backoff state is kept per key so it grows across
+// separate syncPod calls instead of a single goroutine-blocking loop.
+func (c *Controller) nextPDBBackoff(key string) (time.Duration, bool) {
+	c.pdbRetryMu.Lock()
+	defer c.pdbRetryMu.Unlock()
+
+	state, ok := c.pdbRetryState[key]
+	if !ok {
+		state = &pdbRetry{deadline: time.Now().Add(c.evictionTimeout), backoff: evictionBackoffInitial}
+		c.pdbRetryState[key] = state
+	}
+
+	remaining := time.Until(state.deadline)
+	if remaining <= 0 {
+		delete(c.pdbRetryState, key)
+		return 0, false
+	}
+
+	sleep := state.backoff + time.Duration(rand.Float64()*evictionBackoffJitter*float64(state.backoff))
+	if sleep > remaining {
+		sleep = remaining
+	}
+
+	state.backoff = time.Duration(float64(state.backoff) * evictionBackoffFactor)
+	if state.backoff > evictionBackoffMax {
+		state.backoff = evictionBackoffMax
+	}
+
+	return sleep, true
+}
+
+// hasPDBRetry reports whether key already has an in-progress PodDisruptionBudget retry
+// scheduled, i.e. this is a subsequent pass of an eviction already attempted once, rather
+// than the first sync of a new threshold breach.
+func (c *Controller) hasPDBRetry(key string) bool {
+	c.pdbRetryMu.Lock()
+	defer c.pdbRetryMu.Unlock()
+	_, ok := c.pdbRetryState[key]
+	return ok
+}
+
+// clearPDBRetry forgets key's in-progress PodDisruptionBudget retry state, e.g. once its
+// pod has evicted successfully or is no longer found to be breaching its threshold.
+func (c *Controller) clearPDBRetry(key string) {
+	c.pdbRetryMu.Lock()
+	delete(c.pdbRetryState, key)
+	c.pdbRetryMu.Unlock()
+}
+
+// pollMetrics lists memory metrics once for every pod matching PreoomkillerPodLabelSelector,
+// caches them keyed by namespace/name, and enqueues every pod currently known to the
+// informer so syncPod re-evaluates it against the freshly cached metrics. This replaces
+// doing a PodMetricses.Get per pod with a single batched List per interval.
+func (c *Controller) pollMetrics() {
+	start := time.Now()
+	defer func() {
+		reconcileDurationSeconds.Observe(time.Since(start).Seconds())
+	}()
 
-	podList, err := c.clientset.CoreV1().Pods("").List(meta_v1.ListOptions{
+	podMetricsList, err := c.metricsClientset.MetricsV1beta1().PodMetricses("").List(meta_v1.ListOptions{
 		LabelSelector: PreoomkillerPodLabelSelector,
 	})
 	if err != nil {
-		log.Errorf("PodListError for label selector %s: %s", PreoomkillerPodLabelSelector, err)
+		log.Errorf("PodMetricsListError: %s", err)
+		return
+	}
+
+	metricsCache := make(map[string]*metrics_v1beta1.PodMetrics, len(podMetricsList.Items))
+	for i := range podMetricsList.Items {
+		podMetrics := &podMetricsList.Items[i]
+		metricsCache[podMetrics.Namespace+"/"+podMetrics.Name] = podMetrics
+	}
+	c.metricsMu.Lock()
+	c.metricsCache = metricsCache
+	c.metricsMu.Unlock()
+
+	pods, err := c.podLister.List(labels.Everything())
+	if err != nil {
+		log.Errorf("PodListError: %s", err)
+		return
+	}
+	podsWatched.Set(float64(len(pods)))
+	for _, pod := range pods {
+		c.enqueuePod(pod)
+	}
+}
+
+// metricsFor returns the most recently polled metrics for the pod identified by key
+// (namespace/name), if any.
+func (c *Controller) metricsFor(key string) (*metrics_v1beta1.PodMetrics, bool) {
+	c.metricsMu.Lock()
+	defer c.metricsMu.Unlock()
+	podMetrics, ok := c.metricsCache[key]
+	return podMetrics, ok
+}
+
+// setKnownContainers records containerNames as the set of containers syncPod just set
+// podMemoryUsageBytes/podMemoryThresholdBytes labels for, for the pod identified by key.
+func (c *Controller) setKnownContainers(key string, containerNames []string) {
+	c.knownContainersMu.Lock()
+	c.knownContainers[key] = containerNames
+	c.knownContainersMu.Unlock()
+}
+
+// evictionResults enumerates the "result" label values evictionsTotal is incremented
+// with, so deletePodMetrics can drop every series a pod may have contributed to it.
+var evictionResults = [...]string{"success", "error", "skipped"}
+
+// deletePodMetrics removes the podMemoryUsageBytes/podMemoryThresholdBytes series for
+// every container last known for the pod identified by key (namespace, name), along with
+// its evictionsTotal series, so a deleted pod doesn't leave its label combinations behind
+// forever.
+func (c *Controller) deletePodMetrics(key, namespace, name string) {
+	c.knownContainersMu.Lock()
+	containerNames := c.knownContainers[key]
+	delete(c.knownContainers, key)
+	c.knownContainersMu.Unlock()
+
+	for _, containerName := range containerNames {
+		podMemoryUsageBytes.DeleteLabelValues(namespace, name, containerName)
+		podMemoryThresholdBytes.DeleteLabelValues(namespace, name, containerName)
+	}
+
+	for _, result := range evictionResults {
+		evictionsTotal.DeleteLabelValues(namespace, name, result)
+	}
+}
+
+// runWorker drains syncPod work items from the queue until it is shut down.
+func (c *Controller) runWorker() {
+	for c.processNextWorkItem() {
+	}
+}
+
+// processNextWorkItem pops one key off the queue and reconciles it, requeueing with
+// backoff on error. It returns false once the queue has been shut down.
+func (c *Controller) processNextWorkItem() bool {
+	key, shutdown := c.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer c.queue.Done(key)
+
+	if err := c.syncPod(key.(string)); err != nil {
+		log.Errorf("SyncPodError for %s: %s", key, err)
+		c.queue.AddRateLimited(key)
+		return true
+	}
+
+	c.queue.Forget(key)
+	return true
+}
+
+// syncPod reconciles a single pod identified by key (namespace/name): it checks
+// whether eviction is prevented or in cooldown, evaluates cached container metrics
+// against thresholds, and evicts the pod if any container has breached its threshold.
+func (c *Controller) syncPod(key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return fmt.Errorf("invalid resource key %q: %w", key, err)
+	}
+
+	pod, err := c.podLister.Pods(namespace).Get(name)
+	if apierrors.IsNotFound(err) {
+		c.clearPDBRetry(key)
+		c.deletePodMetrics(key, namespace, name)
+		return nil
+	}
+	if err != nil {
 		return err
 	}
 
-	for _, pod := range podList.Items {
-		podName, podNamespace := pod.ObjectMeta.Name, pod.ObjectMeta.Namespace
-		podMemoryThreshold, err := resource.ParseQuantity(pod.ObjectMeta.Annotations[PreoomkillerAnnotationMemoryThresholdKey])
+	podName, podNamespace := pod.ObjectMeta.Name, pod.ObjectMeta.Namespace
+	podLog := log.WithFields(log.Fields{
+		"pod":       podName,
+		"namespace": podNamespace,
+	})
+
+	if c.preventsEviction(pod) {
+		podLog.Debug("PodEvictionSkipped: prevent-eviction annotation or label set")
+		c.eventRecorder.Event(pod, core_v1.EventTypeNormal, "PreOOMEvictionPrevented", "Skipped: prevent-eviction annotation or namespace label set")
+		evictionsTotal.WithLabelValues(podNamespace, podName, "skipped").Inc()
+		return nil
+	}
+
+	if c.inCooldown(pod.ObjectMeta.UID) {
+		podLog.Debug("PodEvictionSkipped: pod is within its eviction cooldown")
+		c.eventRecorder.Eventf(pod, core_v1.EventTypeNormal, "PreOOMEvictionCooldown", "Skipped: pod was evicted within the last %s", c.evictionCooldown)
+		evictionsTotal.WithLabelValues(podNamespace, podName, "skipped").Inc()
+		return nil
+	}
+
+	podMetrics, ok := c.metricsFor(key)
+	if !ok {
+		podLog.Debug("PodMetricsNotCached: no metrics polled yet for this pod")
+		return nil
+	}
+
+	containersByName := make(map[string]core_v1.Container, len(pod.Spec.Containers))
+	for _, container := range pod.Spec.Containers {
+		containersByName[container.Name] = container
+	}
+
+	exceeded := false
+	var breachedContainer string
+	var breachedUsage, breachedThreshold resource.Quantity
+	var containerNames []string
+	for _, containerMetrics := range podMetrics.Containers {
+		container, ok := containersByName[containerMetrics.Name]
+		if !ok {
+			continue
+		}
+
+		containerThreshold, err := containerMemoryThreshold(pod, container, c.defaultMemoryThresholdPercent)
 		if err != nil {
-			log.WithFields(log.Fields{
-				"pod":       podName,
-				"namespace": podNamespace,
-			}).Errorf("PodMemoryThresholdFetchError: %s", err)
+			podLog.WithField("container", container.Name).Errorf("ContainerMemoryThresholdFetchError: %s", err)
 			continue
 		}
 
-		podLog := log.WithFields(log.Fields{
-			"pod":             podName,
-			"namespace":       podNamespace,
-			"memoryThreshold": podMemoryThreshold.String(),
-		})
+		containerUsage := containerMetrics.Usage.Memory()
+		containerNames = append(containerNames, container.Name)
+		podMemoryUsageBytes.WithLabelValues(podNamespace, podName, container.Name).Set(float64(containerUsage.Value()))
+		podMemoryThresholdBytes.WithLabelValues(podNamespace, podName, container.Name).Set(float64(containerThreshold.Value()))
 
-		podMemoryUsage := &resource.Quantity{}
+		podLog.WithFields(log.Fields{
+			"container":       container.Name,
+			"memoryUsage":     containerUsage.String(),
+			"memoryThreshold": containerThreshold.String(),
+		}).Debugf("Container memory usage for %s: %s (cpu), %s (mem)", container.Name, containerMetrics.Usage.Cpu().String(), containerUsage.String())
 
-		podMetrics, err := c.metricsClientset.MetricsV1beta1().PodMetricses(podNamespace).Get(podName, meta_v1.GetOptions{})
-		if err != nil {
-			podLog.Errorf("PodMetricsFetchError: %s", err)
-			return err
+		if containerUsage.Cmp(containerThreshold) == 1 {
+			exceeded = true
+			breachedContainer, breachedUsage, breachedThreshold = container.Name, *containerUsage, containerThreshold
+			podLog.WithFields(log.Fields{
+				"container":       container.Name,
+				"memoryUsage":     containerUsage.String(),
+				"memoryThreshold": containerThreshold.String(),
+			}).Infof("ContainerMemoryThresholdExceeded")
 		}
+	}
+	c.setKnownContainers(key, containerNames)
+
+	if !exceeded {
+		c.clearPDBRetry(key)
+		return nil
+	}
+
+	if c.evictionRateLimiter != nil && !c.evictionRateLimiter.Allow() {
+		podLog.Infof("PodEvictionSkipped: max-evictions-per-minute rate limit reached, retrying next interval")
+		evictionsTotal.WithLabelValues(podNamespace, podName, "skipped").Inc()
+		return nil
+	}
+
+	// Only run the drain hook on the first eviction attempt for this breach: once a
+	// PodDisruptionBudget-blocked Eviction has been requeued, later syncPod passes for
+	// the same key are retries of that same attempt, not a new breach, and the hook
+	// (which may be a non-idempotent HTTP call or exec script) must not re-run for each.
+	if !c.hasPDBRetry(key) {
+		c.runPreEvictHook(pod)
+	}
 
-		for _, containerMetrics := range podMetrics.Containers {
-			podMemoryUsage.Add(*containerMetrics.Usage.Memory())
-			podLog.Debugf("Container metrics for %s: %s (cpu), %s (mem)", containerMetrics.Name, containerMetrics.Usage.Cpu().String(), containerMetrics.Usage.Memory().String())
+	grace := gracePeriodSeconds(pod)
+	if _, err := c.evictPodWithRetry(key, podName, podNamespace, grace, false); err != nil {
+		if err == errEvictionRetryQueued {
+			podLog.Infof("PodEvictionRetry: blocked by PodDisruptionBudget, retry requeued with backoff")
+			return nil
 		}
-		podLog.Debugf("Pod memory usage: %v", podMemoryUsage.String())
-		if podMemoryUsage.Cmp(podMemoryThreshold) == 1 {
-			_, err := evictPod(c.clientset, podName, podNamespace, "v1", false)
-			if err != nil {
-				podLog.Errorf("PodEvictionError: %v", err)
-			} else {
-				evictionCount += 1
-				podLog.Infof("PodEvicted with memory usage: %v", podMemoryUsage)
+
+		if apierrors.IsTooManyRequests(err) && forceOnPDB(pod) {
+			podLog.Warnf("PodEvictionBlocked: %v; force-on-pdb set, falling back to hard delete", err)
+			if delErr := c.clientset.CoreV1().Pods(podNamespace).Delete(podName, &meta_v1.DeleteOptions{GracePeriodSeconds: grace}); delErr != nil {
+				podLog.Errorf("PodForceDeleteError: %v", delErr)
+				evictionsTotal.WithLabelValues(podNamespace, podName, "error").Inc()
+				evictionErrorsTotal.WithLabelValues(string(apierrors.ReasonForError(delErr))).Inc()
+				c.eventRecorder.Eventf(pod, core_v1.EventTypeWarning, "PreOOMEvictionFailed", "Blocked by PodDisruptionBudget and force-on-pdb delete failed: container %s memory usage %s exceeded threshold %s: %v", breachedContainer, breachedUsage.String(), breachedThreshold.String(), delErr)
+				return nil
 			}
+
+			c.clearPDBRetry(key)
+			c.recordEviction(pod.ObjectMeta.UID)
+			evictionsTotal.WithLabelValues(podNamespace, podName, "success").Inc()
+			c.eventRecorder.Eventf(pod, core_v1.EventTypeWarning, "PreOOMForceDeleted", "Force-deleted (PodDisruptionBudget blocked eviction): container %s memory usage %s exceeded threshold %s", breachedContainer, breachedUsage.String(), breachedThreshold.String())
+			podLog.Warnf("PodForceDeleted due to container memory threshold breach, PodDisruptionBudget blocked normal eviction")
+			return nil
 		}
+
+		c.clearPDBRetry(key)
+		podLog.Errorf("PodEvictionError: %v", err)
+		evictionsTotal.WithLabelValues(podNamespace, podName, "error").Inc()
+		evictionErrorsTotal.WithLabelValues(string(apierrors.ReasonForError(err))).Inc()
+		c.eventRecorder.Eventf(pod, core_v1.EventTypeWarning, "PreOOMEvictionFailed", "Failed to evict: container %s memory usage %s exceeded threshold %s: %v", breachedContainer, breachedUsage.String(), breachedThreshold.String(), err)
+		return nil
 	}
-	log.Infof("%d pods evicted during this run", evictionCount)
+
+	c.clearPDBRetry(key)
+	c.recordEviction(pod.ObjectMeta.UID)
+	evictionsTotal.WithLabelValues(podNamespace, podName, "success").Inc()
+	c.eventRecorder.Eventf(pod, core_v1.EventTypeNormal, "PreOOMEvicted", "Evicted: container %s memory usage %s exceeded threshold %s", breachedContainer, breachedUsage.String(), breachedThreshold.String())
+	podLog.Infof("PodEvicted due to container memory threshold breach")
 	return nil
 }
 
-// Run runs RunOnce in a loop with a delay until stopCh receives a value.
+// Run starts the pod and namespace informers, launches numWorkers reconciliation
+// workers, and polls metrics on c.interval until stopCh is closed.
 func (c *Controller) Run(stopCh chan struct{}) {
+	defer runtimeutil.HandleCrash()
+	defer c.queue.ShutDown()
+
+	log.Info("Starting pod and namespace informers and waiting for cache sync")
+	c.informerFactory.Start(stopCh)
+	c.nsInformerFactory.Start(stopCh)
+	if !cache.WaitForCacheSync(stopCh, c.podInformer.HasSynced, c.namespaceInformer.HasSynced) {
+		log.Error("InformerSyncError: timed out waiting for informer caches to sync")
+		return
+	}
+
+	for i := 0; i < numWorkers; i++ {
+		go wait.Until(c.runWorker, time.Second, stopCh)
+	}
+
 	ticker := time.NewTicker(c.interval)
 	defer ticker.Stop()
 
+	c.pollMetrics()
 	for {
-		err := c.RunOnce()
-		if err != nil {
-			log.Error(err)
-		}
 		select {
 		case <-ticker.C:
+			c.pollMetrics()
 		case <-stopCh:
 			log.Info("Terminating main controller loop")
 			return
@@ -153,12 +864,34 @@ func main() {
 	var loglevel string
 	var logformat string
 	var interval int
+	var leaderElect bool
+	var leaderElectLeaseDuration time.Duration
+	var leaderElectRenewDeadline time.Duration
+	var leaderElectRetryPeriod time.Duration
+	var leaderElectResourceName string
+	var leaderElectResourceNamespace string
+	var defaultMemoryThresholdPercent float64
+	var evictionCooldown time.Duration
+	var maxEvictionsPerMinute int
+	var evictionTimeout time.Duration
+	var metricsAddr string
 
 	flag.StringVar(&kubeconfig, "kubeconfig", "", "absolute path to the kubeconfig file")
 	flag.StringVar(&master, "master", "", "master url")
 	flag.IntVar(&interval, "interval", 60, "Interval (in seconds)")
 	flag.StringVar(&loglevel, "loglevel", "info", "Log level, one of debug, info, warn, error")
 	flag.StringVar(&logformat, "logformat", "text", "Log format, one of json, text")
+	flag.BoolVar(&leaderElect, "leader-elect", false, "Enable leader election so only one replica is active at a time")
+	flag.DurationVar(&leaderElectLeaseDuration, "leader-elect-lease-duration", 15*time.Second, "Duration non-leader candidates wait before forcing acquisition")
+	flag.DurationVar(&leaderElectRenewDeadline, "leader-elect-renew-deadline", 10*time.Second, "Duration the leader retries refreshing leadership before giving it up")
+	flag.DurationVar(&leaderElectRetryPeriod, "leader-elect-retry-period", 2*time.Second, "Duration clients should wait between tries of actions")
+	flag.StringVar(&leaderElectResourceName, "leader-elect-resource-name", "preoomkiller-controller", "Name of the Lease resource used for leader election")
+	flag.StringVar(&leaderElectResourceNamespace, "leader-elect-resource-namespace", "kube-system", "Namespace of the Lease resource used for leader election")
+	flag.Float64Var(&defaultMemoryThresholdPercent, "default-memory-threshold-percent", 0, "When set (e.g. 90), used as a container's memory threshold as a percentage of its resources.limits.memory when no memory-threshold annotation is present")
+	flag.DurationVar(&evictionCooldown, "eviction-cooldown", 5*time.Minute, "Minimum time to wait before re-evaluating a pod that was just evicted")
+	flag.IntVar(&maxEvictionsPerMinute, "max-evictions-per-minute", 0, "Global cap on evictions per minute across all pods, 0 disables the limit")
+	flag.DurationVar(&evictionTimeout, "eviction-timeout", 2*time.Minute, "Maximum time to retry an Eviction blocked by a PodDisruptionBudget before giving up on that pod")
+	flag.StringVar(&metricsAddr, "metrics-addr", ":9090", "Address to serve Prometheus metrics on")
 	flag.Set("logtostderr", "true")
 	flag.Parse()
 
@@ -204,19 +937,101 @@ func main() {
 		log.Fatal(err)
 	}
 
-	controller := NewController(clientset, metricsClientset, time.Duration(interval)*time.Second)
+	controller := NewController(clientset, metricsClientset, config, time.Duration(interval)*time.Second, defaultMemoryThresholdPercent, evictionCooldown, maxEvictionsPerMinute, evictionTimeout)
+
+	serveMetrics(metricsAddr)
 
 	// Now let's start the controller
 	stopCh := make(chan struct{})
-	go handleSigterm(stopCh)
-	defer close(stopCh)
-	controller.Run(stopCh)
+	var stopOnce sync.Once
+	stop := func() {
+		stopOnce.Do(func() {
+			log.Info("Terminating...")
+			close(stopCh)
+		})
+	}
+	go handleSigterm(stop)
+
+	if !leaderElect {
+		defer stop()
+		controller.Run(stopCh)
+		return
+	}
+
+	runWithLeaderElection(clientset, leaderElectionOptions{
+		resourceName:      leaderElectResourceName,
+		resourceNamespace: leaderElectResourceNamespace,
+		leaseDuration:     leaderElectLeaseDuration,
+		renewDeadline:     leaderElectRenewDeadline,
+		retryPeriod:       leaderElectRetryPeriod,
+	}, controller, stopCh, stop)
 }
 
-func handleSigterm(stopCh chan struct{}) {
+// handleSigterm invokes stop when the process receives SIGTERM.
+func handleSigterm(stop func()) {
 	signals := make(chan os.Signal, 1)
 	signal.Notify(signals, syscall.SIGTERM)
 	<-signals
-	log.Info("Received SIGTERM. Terminating...")
-	close(stopCh)
+	log.Info("Received SIGTERM")
+	stop()
+}
+
+// leaderElectionOptions configures the Lease used to elect a single active controller
+// replica when running with --leader-elect.
+type leaderElectionOptions struct {
+	resourceName      string
+	resourceNamespace string
+	leaseDuration     time.Duration
+	renewDeadline     time.Duration
+	retryPeriod       time.Duration
+}
+
+// runWithLeaderElection blocks running the controller only while this process holds the
+// Lease identified by opts, so that 2+ replicas can run for failover without racing
+// Evict calls against the API server. stop is invoked on SIGTERM or on losing the lease,
+// which in turn closes stopCh so Controller.Run exits cleanly.
+func runWithLeaderElection(clientset kubernetes.Interface, opts leaderElectionOptions, controller *Controller, stopCh chan struct{}, stop func()) {
+	id, err := os.Hostname()
+	if err != nil {
+		log.Fatalf("LeaderElectionError: unable to determine hostname: %v", err)
+	}
+	id = id + "_" + string(uuid.NewUUID())
+
+	lock, err := resourcelock.New(
+		resourcelock.LeasesResourceLock,
+		opts.resourceNamespace,
+		opts.resourceName,
+		clientset.CoreV1(),
+		clientset.CoordinationV1(),
+		resourcelock.ResourceLockConfig{
+			Identity: id,
+		},
+	)
+	if err != nil {
+		log.Fatalf("LeaderElectionError: unable to create resource lock: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-stopCh
+		cancel()
+	}()
+
+	leaderelection.RunOrDie(ctx, leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		LeaseDuration:   opts.leaseDuration,
+		RenewDeadline:   opts.renewDeadline,
+		RetryPeriod:     opts.retryPeriod,
+		ReleaseOnCancel: true,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				log.WithField("identity", id).Info("Became leader, starting controller")
+				controller.Run(stopCh)
+			},
+			OnStoppedLeading: func() {
+				log.WithField("identity", id).Info("Lost leadership, terminating")
+				stop()
+			},
+		},
+	})
 }
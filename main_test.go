@@ -0,0 +1,194 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	core_v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+	metrics_v1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+)
+
+const testMemoryThreshold = "500Mi"
+
+// newTestPod builds a pod matching PreoomkillerPodLabelSelector, with a single "app"
+// container and the given annotations (e.g. a memory-threshold or prevent-eviction key).
+func newTestPod(name string, annotations map[string]string) *core_v1.Pod {
+	return &core_v1.Pod{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:        name,
+			Namespace:   "default",
+			UID:         types.UID(name + "-uid"),
+			Labels:      map[string]string{"preoomkiller-enabled": "true"},
+			Annotations: annotations,
+		},
+		Spec: core_v1.PodSpec{
+			Containers: []core_v1.Container{{Name: "app"}},
+		},
+	}
+}
+
+// newTestController builds a Controller backed by a fake clientset seeded with pod, with
+// its pod and namespace informer caches synced so syncPod can be called directly.
+func newTestController(t *testing.T, pod *core_v1.Pod, evictionCooldown, evictionTimeout time.Duration) (*Controller, *fake.Clientset) {
+	t.Helper()
+
+	clientset := fake.NewSimpleClientset(pod)
+	c := NewController(clientset, nil, nil, time.Minute, 0, evictionCooldown, 0, evictionTimeout)
+
+	stopCh := make(chan struct{})
+	t.Cleanup(func() { close(stopCh) })
+	c.informerFactory.Start(stopCh)
+	c.nsInformerFactory.Start(stopCh)
+	if !cache.WaitForCacheSync(stopCh, c.podInformer.HasSynced, c.namespaceInformer.HasSynced) {
+		t.Fatal("timed out waiting for informer caches to sync")
+	}
+
+	return c, clientset
+}
+
+// withUsage caches podMetrics reporting usage for pod's "app" container, the way
+// pollMetrics would after a batched metrics List.
+func withUsage(c *Controller, pod *core_v1.Pod, usage string) {
+	key := pod.ObjectMeta.Namespace + "/" + pod.ObjectMeta.Name
+	c.metricsMu.Lock()
+	c.metricsCache[key] = &metrics_v1beta1.PodMetrics{
+		ObjectMeta: meta_v1.ObjectMeta{Name: pod.ObjectMeta.Name, Namespace: pod.ObjectMeta.Namespace},
+		Containers: []metrics_v1beta1.ContainerMetrics{{
+			Name:  "app",
+			Usage: core_v1.ResourceList{core_v1.ResourceMemory: resource.MustParse(usage)},
+		}},
+	}
+	c.metricsMu.Unlock()
+}
+
+// failOnEviction fails the test if anything attempts to evict a pod through clientset,
+// for asserting that syncPod skipped eviction entirely.
+func failOnEviction(t *testing.T, clientset *fake.Clientset) {
+	t.Helper()
+	clientset.PrependReactor("create", "*", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() == "eviction" {
+			t.Fatalf("unexpected eviction attempt: %#v", action)
+		}
+		return false, nil, nil
+	})
+}
+
+// reactToEviction makes every Eviction create against clientset resolve with err (nil
+// for success).
+func reactToEviction(clientset *fake.Clientset, err error) {
+	clientset.PrependReactor("create", "*", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() == "eviction" {
+			return true, nil, err
+		}
+		return false, nil, nil
+	})
+}
+
+func TestSyncPod_ThresholdBreachEvicts(t *testing.T) {
+	pod := newTestPod("breacher", map[string]string{PreoomkillerAnnotationMemoryThresholdKey: testMemoryThreshold})
+	c, clientset := newTestController(t, pod, 5*time.Minute, 2*time.Minute)
+	withUsage(c, pod, "600Mi")
+	reactToEviction(clientset, nil)
+
+	if err := c.syncPod(pod.ObjectMeta.Namespace + "/" + pod.ObjectMeta.Name); err != nil {
+		t.Fatalf("syncPod returned error: %v", err)
+	}
+	if !c.inCooldown(pod.ObjectMeta.UID) {
+		t.Fatal("expected pod to be recorded as evicted")
+	}
+}
+
+func TestSyncPod_NoBreachSkipsEviction(t *testing.T) {
+	pod := newTestPod("healthy", map[string]string{PreoomkillerAnnotationMemoryThresholdKey: testMemoryThreshold})
+	c, clientset := newTestController(t, pod, 5*time.Minute, 2*time.Minute)
+	withUsage(c, pod, "100Mi")
+	failOnEviction(t, clientset)
+
+	if err := c.syncPod(pod.ObjectMeta.Namespace + "/" + pod.ObjectMeta.Name); err != nil {
+		t.Fatalf("syncPod returned error: %v", err)
+	}
+	if c.inCooldown(pod.ObjectMeta.UID) {
+		t.Fatal("expected pod not to be evicted")
+	}
+}
+
+func TestSyncPod_PreventEvictionAnnotationSkipsEviction(t *testing.T) {
+	pod := newTestPod("protected", map[string]string{
+		PreoomkillerAnnotationMemoryThresholdKey: testMemoryThreshold,
+		PreoomkillerAnnotationPreventEvictionKey: "true",
+	})
+	c, clientset := newTestController(t, pod, 5*time.Minute, 2*time.Minute)
+	withUsage(c, pod, "600Mi")
+	failOnEviction(t, clientset)
+
+	if err := c.syncPod(pod.ObjectMeta.Namespace + "/" + pod.ObjectMeta.Name); err != nil {
+		t.Fatalf("syncPod returned error: %v", err)
+	}
+	if c.inCooldown(pod.ObjectMeta.UID) {
+		t.Fatal("expected prevent-eviction annotation to skip eviction")
+	}
+}
+
+func TestSyncPod_CooldownSkipsEviction(t *testing.T) {
+	pod := newTestPod("cooling-down", map[string]string{PreoomkillerAnnotationMemoryThresholdKey: testMemoryThreshold})
+	c, clientset := newTestController(t, pod, 5*time.Minute, 2*time.Minute)
+	withUsage(c, pod, "600Mi")
+	c.recordEviction(pod.ObjectMeta.UID)
+	failOnEviction(t, clientset)
+
+	if err := c.syncPod(pod.ObjectMeta.Namespace + "/" + pod.ObjectMeta.Name); err != nil {
+		t.Fatalf("syncPod returned error: %v", err)
+	}
+}
+
+func TestSyncPod_PDBBlockedRequeuesWithBackoff(t *testing.T) {
+	pod := newTestPod("pdb-blocked", map[string]string{PreoomkillerAnnotationMemoryThresholdKey: testMemoryThreshold})
+	c, clientset := newTestController(t, pod, 5*time.Minute, 2*time.Minute)
+	withUsage(c, pod, "600Mi")
+	reactToEviction(clientset, apierrors.NewTooManyRequests("blocked by PodDisruptionBudget", 0))
+
+	key := pod.ObjectMeta.Namespace + "/" + pod.ObjectMeta.Name
+	if err := c.syncPod(key); err != nil {
+		t.Fatalf("syncPod returned error: %v", err)
+	}
+	if c.inCooldown(pod.ObjectMeta.UID) {
+		t.Fatal("expected pod not to be recorded as evicted while PodDisruptionBudget-blocked")
+	}
+
+	c.pdbRetryMu.Lock()
+	_, retrying := c.pdbRetryState[key]
+	c.pdbRetryMu.Unlock()
+	if !retrying {
+		t.Fatal("expected a PodDisruptionBudget retry to be scheduled instead of blocking")
+	}
+}
+
+func TestSyncPod_ForceOnPDBFallsBackToDelete(t *testing.T) {
+	pod := newTestPod("force-delete-me", map[string]string{
+		PreoomkillerAnnotationMemoryThresholdKey: testMemoryThreshold,
+		PreoomkillerAnnotationForceOnPDBKey:      "true",
+	})
+	// An eviction timeout of 0 means the first PodDisruptionBudget-blocked attempt
+	// already has no retry budget left, exercising the force-on-pdb fallback path.
+	c, clientset := newTestController(t, pod, 5*time.Minute, 0)
+	withUsage(c, pod, "600Mi")
+	reactToEviction(clientset, apierrors.NewTooManyRequests("blocked by PodDisruptionBudget", 0))
+
+	if err := c.syncPod(pod.ObjectMeta.Namespace + "/" + pod.ObjectMeta.Name); err != nil {
+		t.Fatalf("syncPod returned error: %v", err)
+	}
+	if !c.inCooldown(pod.ObjectMeta.UID) {
+		t.Fatal("expected pod to be recorded as evicted via the force-delete fallback")
+	}
+	if _, err := clientset.CoreV1().Pods(pod.ObjectMeta.Namespace).Get(pod.ObjectMeta.Name, meta_v1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Fatalf("expected pod to have been force-deleted, got err=%v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// evictionsTotal is cleared per pod (across every result value) via DeleteLabelValues
+	// in Controller.deletePodMetrics once syncPod observes a pod is gone.
+	evictionsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "preoomkiller_evictions_total",
+		Help: "Total number of pod eviction attempts, by result (success, error, skipped)",
+	}, []string{"namespace", "pod", "result"})
+
+	evictionErrorsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "preoomkiller_eviction_errors_total",
+		Help: "Total number of pod eviction errors, by reason",
+	}, []string{"reason"})
+
+	// podMemoryUsageBytes and podMemoryThresholdBytes are cleared per container via
+	// DeleteLabelValues in Controller.deletePodMetrics once syncPod observes a pod is
+	// gone, so deleted/evicted pods don't leave stale series behind.
+	podMemoryUsageBytes = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "preoomkiller_pod_memory_usage_bytes",
+		Help: "Most recently observed memory usage for a watched pod's container",
+	}, []string{"namespace", "pod", "container"})
+
+	podMemoryThresholdBytes = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "preoomkiller_pod_memory_threshold_bytes",
+		Help: "Memory threshold in effect for a watched pod's container",
+	}, []string{"namespace", "pod", "container"})
+
+	reconcileDurationSeconds = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name: "preoomkiller_reconcile_duration_seconds",
+		Help: "Time taken by a single batched pod metrics poll",
+	})
+
+	podsWatched = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "preoomkiller_pods_watched",
+		Help: "Number of pods currently matching the preoomkiller label selector",
+	})
+)
+
+// serveMetrics exposes the preoomkiller_* Prometheus metrics on addr at /metrics.
+func serveMetrics(addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			log.Errorf("MetricsServerError: %s", err)
+		}
+	}()
+}